@@ -0,0 +1,195 @@
+package space
+
+import (
+	"container/list"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheStats is a snapshot of ThumbnailCache's bookkeeping, returned
+// by the /admin/cache/stats handler.
+type CacheStats struct {
+	Entries  int
+	Bytes    int64
+	MaxBytes int64
+}
+
+type cacheEntry struct {
+	path string
+	size int64
+
+	// accessedAt is updated on every serve without touching the
+	// file's mtime, so http.ServeContent's Last-Modified stays stable.
+	accessedAt atomic.Int64
+
+	elem *list.Element
+}
+
+// ThumbnailCache bounds the on-disk thumbnails under
+// ServerConfig.ThumbnailDir, evicting least-recently-served entries
+// once the total size exceeds MaxBytes, and entries older than MaxAge
+// regardless of size.
+type ThumbnailCache struct {
+	dir      string
+	maxBytes int64
+	maxAge   time.Duration
+
+	mu    sync.Mutex
+	index map[string]*cacheEntry
+	lru   *list.List // front = most recently served
+	size  int64
+}
+
+// NewThumbnailCache builds a ThumbnailCache for dir, populating its
+// index by walking the existing thumbnails on disk. maxBytes <= 0
+// disables size-based eviction; maxAge <= 0 disables age-based
+// eviction.
+func NewThumbnailCache(dir string, maxBytes int64, maxAge time.Duration) (*ThumbnailCache, error) {
+	c := &ThumbnailCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		maxAge:   maxAge,
+		index:    make(map[string]*cacheEntry),
+		lru:      list.New(),
+	}
+
+	type found struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var entries []found
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() || strings.HasPrefix(filepath.Base(path), "tmp") {
+			return nil
+		}
+		entries = append(entries, found{path: path, size: info.Size(), modTime: info.ModTime()})
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// filepath.Walk visits in lexical order, not recency order. Add
+	// oldest-first so the LRU ends up front=newest/back=oldest, same
+	// as it would if these thumbnails had been added one at a time.
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+	for _, e := range entries {
+		c.add(e.path, e.size, e.modTime)
+	}
+
+	return c, nil
+}
+
+// add records or refreshes path's size in the cache, evicting entries
+// if that pushes the cache over its bounds.
+func (c *ThumbnailCache) add(path string, size int64, accessedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.index[path]; ok {
+		c.size += size - e.size
+		e.size = size
+		e.accessedAt.Store(accessedAt.UnixNano())
+		c.lru.MoveToFront(e.elem)
+		c.evictLocked(e.elem)
+		return
+	}
+
+	e := &cacheEntry{path: path, size: size}
+	e.accessedAt.Store(accessedAt.UnixNano())
+	e.elem = c.lru.PushFront(e)
+	c.index[path] = e
+	c.size += size
+
+	c.evictLocked(e.elem)
+}
+
+// touch marks path as just served, moving it to the front of the LRU.
+func (c *ThumbnailCache) touch(path string) {
+	c.mu.Lock()
+	e, ok := c.index[path]
+	if ok {
+		c.lru.MoveToFront(e.elem)
+	}
+	c.mu.Unlock()
+
+	if ok {
+		e.accessedAt.Store(time.Now().UnixNano())
+	}
+}
+
+// evictLocked removes expired entries, then least-recently-served
+// entries until the cache is back within MaxBytes. protect is never
+// evicted, even if it alone exceeds MaxBytes — it's the entry add()
+// just created or refreshed, and evicting it out from under the
+// caller would delete a thumbnail the instant it's written. Callers
+// must hold c.mu.
+func (c *ThumbnailCache) evictLocked(protect *list.Element) {
+	if c.maxAge > 0 {
+		cutoff := time.Now().Add(-c.maxAge).UnixNano()
+		for e := c.lru.Back(); e != nil && e != protect; {
+			entry := e.Value.(*cacheEntry)
+			if entry.accessedAt.Load() >= cutoff {
+				break
+			}
+			prev := e.Prev()
+			c.removeLocked(e, entry)
+			e = prev
+		}
+	}
+
+	if c.maxBytes > 0 {
+		for c.size > c.maxBytes {
+			back := c.lru.Back()
+			if back == nil || back == protect {
+				return
+			}
+			c.removeLocked(back, back.Value.(*cacheEntry))
+		}
+	}
+}
+
+func (c *ThumbnailCache) removeLocked(e *list.Element, entry *cacheEntry) {
+	c.lru.Remove(e)
+	delete(c.index, entry.path)
+	c.size -= entry.size
+	os.Remove(entry.path)
+}
+
+// Stats returns a snapshot of the cache's current size.
+func (c *ThumbnailCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return CacheStats{
+		Entries:  len(c.index),
+		Bytes:    c.size,
+		MaxBytes: c.maxBytes,
+	}
+}
+
+// Purge deletes every cached thumbnail from disk and empties the index.
+func (c *ThumbnailCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for path := range c.index {
+		os.Remove(path)
+	}
+	c.index = make(map[string]*cacheEntry)
+	c.lru = list.New()
+	c.size = 0
+}
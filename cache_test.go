@@ -0,0 +1,81 @@
+package space
+
+import (
+	"container/list"
+	"testing"
+	"time"
+)
+
+func TestThumbnailCacheAddEvictsOldestOverSizeBudget(t *testing.T) {
+	c := &ThumbnailCache{
+		maxBytes: 10,
+		index:    make(map[string]*cacheEntry),
+		lru:      list.New(),
+	}
+
+	now := time.Now()
+	c.add("a", 5, now)
+	c.add("b", 5, now.Add(time.Second))
+	c.add("c", 5, now.Add(2*time.Second))
+
+	if _, ok := c.index["a"]; ok {
+		t.Fatalf("oldest entry %q should have been evicted, index=%v", "a", keys(c.index))
+	}
+	if _, ok := c.index["b"]; !ok {
+		t.Fatalf("entry %q should still be cached", "b")
+	}
+	if _, ok := c.index["c"]; !ok {
+		t.Fatalf("entry %q should still be cached", "c")
+	}
+	if c.size != 10 {
+		t.Fatalf("size = %d, want 10", c.size)
+	}
+}
+
+func TestThumbnailCacheAddNeverEvictsTheEntryItJustAddedOrRefreshed(t *testing.T) {
+	c := &ThumbnailCache{
+		maxBytes: 1,
+		index:    make(map[string]*cacheEntry),
+		lru:      list.New(),
+	}
+
+	c.add("a", 100, time.Now())
+	if _, ok := c.index["a"]; !ok {
+		t.Fatalf("entry that alone exceeds maxBytes must not be evicted right after being added")
+	}
+
+	c.add("a", 200, time.Now())
+	if _, ok := c.index["a"]; !ok {
+		t.Fatalf("entry must not be evicted by its own refresh, even over budget")
+	}
+	if c.size != 200 {
+		t.Fatalf("size = %d, want 200", c.size)
+	}
+}
+
+func TestThumbnailCacheAddEvictsExpiredByAge(t *testing.T) {
+	c := &ThumbnailCache{
+		maxAge: time.Minute,
+		index:  make(map[string]*cacheEntry),
+		lru:    list.New(),
+	}
+
+	old := time.Now().Add(-time.Hour)
+	c.add("a", 5, old)
+	c.add("b", 5, time.Now())
+
+	if _, ok := c.index["a"]; ok {
+		t.Fatalf("entry older than maxAge should have been evicted")
+	}
+	if _, ok := c.index["b"]; !ok {
+		t.Fatalf("entry within maxAge should still be cached")
+	}
+}
+
+func keys(m map[string]*cacheEntry) []string {
+	ks := make([]string, 0, len(m))
+	for k := range m {
+		ks = append(ks, k)
+	}
+	return ks
+}
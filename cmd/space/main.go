@@ -3,6 +3,8 @@ package main
 import (
 	"github.com/szxp/space"
 	"github.com/szxp/space/imagemagick"
+	"github.com/szxp/space/imaging"
+	"github.com/szxp/space/preprocess"
 
 	"context"
 	"flag"
@@ -48,21 +50,45 @@ func main() {
 }
 
 func initialize(conf *config, logger hclog.Logger) error {
-	imVer, err := imagemagick.Version()
+	resizer, err := newImageResizer(conf, logger)
 	if err != nil {
-		return fmt.Errorf("Failed to check Imagemagick version: %w", err)
+		return err
+	}
+
+	presets := make(space.Presets, 0, len(conf.Preset))
+	for _, p := range conf.Preset {
+		presets = append(presets, &space.Preset{
+			Name:    p.Name,
+			Width:   p.Width,
+			Height:  p.Height,
+			Mode:    p.Mode,
+			Quality: p.Quality,
+		})
+	}
+
+	preprocessors, err := newPreprocessors(conf.Preprocessors)
+	if err != nil {
+		return err
 	}
-	logger.Info("Imagemagick version", "version", imVer)
 
 	handler, err := space.NewServer(space.ServerConfig{
-		SourceDir:             conf.SourceDir,
-		ThumbnailDir:          conf.ThumbnailDir,
-		AllowedExts:           conf.AllowedExtensions,
-		ImageResizer:          &imagemagick.ImageResizer{},
-		DefaultThumbnailWidth: conf.DefaultThumbnailWidth,
-		AllowedThumbnailSizes: conf.AllowedThumbnailSizes,
-		ThumbnailMaxAge:       conf.ThumbnailMaxAge,
-		Logger:                logger.Named("httpserver"),
+		SourceDir:              conf.SourceDir,
+		ThumbnailDir:           conf.ThumbnailDir,
+		AllowedExts:            conf.AllowedExtensions,
+		ImageResizer:           resizer,
+		DefaultThumbnailWidth:  conf.DefaultThumbnailWidth,
+		AllowedThumbnailSizes:  conf.AllowedThumbnailSizes,
+		ThumbnailMaxAge:        conf.ThumbnailMaxAge,
+		AnimatedThumbnails:     conf.AnimatedThumbnails,
+		FaceCascadeFile:        conf.FaceCascadeFile,
+		Presets:                presets,
+		AllowLegacySizes:       conf.AllowLegacySizes,
+		AllowedOutputFormats:   conf.AllowedOutputFormats,
+		ThumbnailCacheMaxBytes: conf.ThumbnailCacheMaxBytes,
+		ThumbnailCacheMaxAge:   conf.ThumbnailCacheMaxAge,
+		AdminToken:             conf.AdminToken,
+		Preprocessors:          preprocessors,
+		Logger:                 logger.Named("httpserver"),
 	})
 	if err != nil {
 		return err
@@ -96,6 +122,48 @@ func initialize(conf *config, logger hclog.Logger) error {
 	return nil
 }
 
+// newImageResizer builds the space.ImageResizer backend selected by
+// conf.Backend. The default, "imagemagick", shells out to the convert
+// binary. "imaging" uses a pure Go decoder/resizer and requires no
+// external dependency.
+func newImageResizer(conf *config, logger hclog.Logger) (space.ImageResizer, error) {
+	switch conf.Backend {
+	case "", "imagemagick":
+		imVer, err := imagemagick.Version()
+		if err != nil {
+			return nil, fmt.Errorf("Failed to check Imagemagick version: %w", err)
+		}
+		logger.Info("Imagemagick version", "version", imVer)
+		return &imagemagick.ImageResizer{}, nil
+	case "imaging":
+		logger.Info("Using pure Go imaging backend")
+		return &imaging.ImageResizer{Quality: conf.ImageQuality}, nil
+	default:
+		return nil, fmt.Errorf("unknown backend: %s", conf.Backend)
+	}
+}
+
+// newPreprocessors builds the space.Preprocessor set selected by
+// names, e.g. "pdf", "heic", "svg", "video".
+func newPreprocessors(names []string) ([]space.Preprocessor, error) {
+	preprocessors := make([]space.Preprocessor, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case "pdf":
+			preprocessors = append(preprocessors, &preprocess.PDFRasterizer{})
+		case "heic":
+			preprocessors = append(preprocessors, &preprocess.HEICConverter{})
+		case "svg":
+			preprocessors = append(preprocessors, &preprocess.SVGRasterizer{})
+		case "video":
+			preprocessors = append(preprocessors, &preprocess.VideoFrameExtractor{})
+		default:
+			return nil, fmt.Errorf("unknown preprocessor: %s", name)
+		}
+	}
+	return preprocessors, nil
+}
+
 func readConfig(path string) (*config, error) {
 	config := &config{}
 	_, err := toml.DecodeFile(path, config)
@@ -112,8 +180,72 @@ type config struct {
 	DefaultThumbnailWidth uint64
 	AllowedThumbnailSizes space.ThumbnailSizes
 	ThumbnailMaxAge       int64
-	HTTPServer            httpServer
-	Log log
+
+	// AnimatedThumbnails, when true, serves animated thumbnails for
+	// animated GIF sources instead of their first frame.
+	AnimatedThumbnails bool
+
+	// FaceCascadeFile was requested for the smart crop face-detection
+	// fallback but is explicitly descoped and not implemented; it's
+	// tracked as a separate follow-up request. Leave it empty --
+	// space.NewServer errors if it's set.
+	FaceCascadeFile string
+
+	// Preset is the [[Preset]] TOML table defining the named
+	// thumbnail presets.
+	Preset []presetConfig
+
+	// AllowLegacySizes, when true, keeps accepting the legacy w/h/m
+	// query params alongside presets. Only relevant once [[Preset]]
+	// is configured: with no presets defined, legacy sizes are always
+	// accepted regardless of this setting. Set it to false once
+	// presets are rolled out to require every request to name one.
+	AllowLegacySizes bool
+
+	// AllowedOutputFormats is the ordered allowlist of output
+	// formats (e.g. "webp", "jpg") content negotiation may pick via
+	// the Accept header.
+	AllowedOutputFormats []string
+
+	// ThumbnailCacheMaxBytes bounds the total size of ThumbnailDir.
+	// Zero disables size-based eviction. Set it above the largest
+	// thumbnail the server can produce, or that single thumbnail
+	// will be kept despite the bound rather than evicted on creation.
+	ThumbnailCacheMaxBytes int64
+
+	// ThumbnailCacheMaxAge evicts cached thumbnails not served
+	// within this many seconds. Zero disables age-based eviction.
+	ThumbnailCacheMaxAge int64
+
+	// AdminToken guards /admin/cache/stats and /admin/cache/purge.
+	// Empty disables both endpoints.
+	AdminToken string
+
+	// Preprocessors selects the built-in space.Preprocessor set by
+	// name: "pdf", "heic", "svg", "video".
+	Preprocessors []string
+
+	// Backend selects the space.ImageResizer implementation:
+	// "imagemagick" (default) or "imaging" for the pure Go backend.
+	Backend string
+
+	// ImageQuality is the JPEG encoding quality used by the
+	// "imaging" backend. Zero means the backend default.
+	ImageQuality int
+
+	HTTPServer httpServer
+	Log        log
+}
+
+type presetConfig struct {
+	Name   string
+	Width  uint64
+	Height uint64
+	Mode   int8
+
+	// Quality is the output encoding quality for lossy formats.
+	// Zero means the backend's own default.
+	Quality int
 }
 
 type httpServer struct {
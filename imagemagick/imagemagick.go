@@ -9,7 +9,7 @@ import (
 
 type ImageResizer struct{}
 
-func (r *ImageResizer) Resize(dst, src string, width, height uint64, mode int8) error {
+func resizeGeometry(width, height uint64, mode int8) string {
 	var size string
 	switch {
 	case width > 0 && height > 0:
@@ -26,12 +26,39 @@ func (r *ImageResizer) Resize(dst, src string, width, height uint64, mode int8)
 	case space.ResizeModeStretch:
 		size += "!"
 	}
+	return size
+}
+
+// outputSpec prefixes dst with an explicit ImageMagick format
+// specifier (e.g. "webp:dst") when format is set, since dst is a
+// temp file without an extension convert could infer the format from.
+func outputSpec(dst, format string) string {
+	if format == "" {
+		return dst
+	}
+	return format + ":" + dst
+}
+
+// resizeQuality returns quality as a string for the "-quality" arg,
+// falling back to 75 when the caller (a preset without Quality set,
+// or no preset at all) didn't request a specific one.
+func resizeQuality(quality int) string {
+	if quality <= 0 {
+		quality = 75
+	}
+	return fmt.Sprintf("%d", quality)
+}
+
+func (r *ImageResizer) Resize(dst, src string, width, height uint64, mode int8, format string, quality int) error {
+	if mode == space.ResizeModeSmartCrop {
+		return r.resizeSmartCrop(dst, src, width, height, format, quality)
+	}
 
 	args := []string{
 		// use only the first frame
 		src + "[0]",
 
-		"-resize", size,
+		"-resize", resizeGeometry(width, height, mode),
 
 		// reads and resets the EXIF image profile setting 'Orientation' and then performs the appropriate 90 degree rotation on the image to orient the image, for correct viewing
 		"-auto-orient",
@@ -40,7 +67,7 @@ func (r *ImageResizer) Resize(dst, src string, width, height uint64, mode int8)
 		//"+profile", "\"*\"",
 
 		"-strip",
-		"-quality", "75",
+		"-quality", resizeQuality(quality),
 	}
 
 	if mode == space.ResizeModeCover {
@@ -52,7 +79,7 @@ func (r *ImageResizer) Resize(dst, src string, width, height uint64, mode int8)
 		)
 	}
 
-	args = append(args, dst)
+	args = append(args, outputSpec(dst, format))
 
 	_, err := exec.Command("convert", args...).Output()
 	if err != nil {
@@ -61,6 +88,68 @@ func (r *ImageResizer) Resize(dst, src string, width, height uint64, mode int8)
 	return nil
 }
 
+// resizeSmartCrop fills width x height like ResizeModeCover, but
+// crops around the highest-entropy region of the source instead of
+// always cropping from the center.
+func (r *ImageResizer) resizeSmartCrop(dst, src string, width, height uint64, format string, quality int) error {
+	cropArgs, err := smartCropArgs(src, width, height)
+	if err != nil {
+		return fmt.Errorf("failed to compute smart crop: %w", err)
+	}
+
+	args := append([]string{src + "[0]"}, cropArgs...)
+	args = append(args, "-auto-orient", "-strip", "-quality", resizeQuality(quality), outputSpec(dst, format))
+
+	_, err = exec.Command("convert", args...).Output()
+	if err != nil {
+		return fmt.Errorf("Failed to create thumbnail: %w", err)
+	}
+	return nil
+}
+
+// ResizeAnimated resizes every frame of an animated source (currently
+// only animated GIF) and re-encodes the result as an animated image,
+// instead of collapsing it to its first frame like Resize does. Only
+// GIF output is supported, since that's the only animated format
+// handled so far.
+func (r *ImageResizer) ResizeAnimated(dst, src string, width, height uint64, mode int8, format string, quality int) error {
+	if format != "" && format != "gif" {
+		return fmt.Errorf("animated thumbnails only support gif output, got %q", format)
+	}
+	if mode == space.ResizeModeSmartCrop {
+		return fmt.Errorf("smart crop is not supported for animated thumbnails")
+	}
+
+	args := []string{
+		src,
+
+		// decode each frame to its full canvas size before resizing,
+		// otherwise frames that only cover part of the canvas would
+		// be resized relative to their own (usually smaller) bounds.
+		"-coalesce",
+
+		"-resize", resizeGeometry(width, height, mode),
+		"-auto-orient",
+		"-strip",
+	}
+
+	if mode == space.ResizeModeCover {
+		args = append(args,
+			"-gravity", "center",
+			"-crop", fmt.Sprintf("%dx%d+0+0", width, height),
+			"+repage",
+		)
+	}
+
+	args = append(args, "-layers", "Optimize", outputSpec(dst, "gif"))
+
+	_, err := exec.Command("convert", args...).Output()
+	if err != nil {
+		return fmt.Errorf("Failed to create animated thumbnail: %w", err)
+	}
+	return nil
+}
+
 func Version() (string, error) {
 	ver, err := exec.Command("convert", "-version").Output()
 	if err != nil {
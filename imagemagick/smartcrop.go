@@ -0,0 +1,145 @@
+package imagemagick
+
+import (
+	"fmt"
+	"math"
+	"os/exec"
+)
+
+// entropyGridSize is the side length of the downsampled grayscale
+// grid that the saliency scan runs over.
+const entropyGridSize = 32
+
+// smartCropArgs returns the convert args that resize src to fill
+// width x height and crop it around the window of highest entropy,
+// in place of the fixed "-gravity center" crop ResizeModeCover uses.
+//
+// There's no face-detection fallback here: space.ServerConfig.FaceCascadeFile
+// is explicitly descoped and rejected by NewServer, not silently ignored.
+func smartCropArgs(src string, width, height uint64) ([]string, error) {
+	srcW, srcH, err := imageDimensions(src)
+	if err != nil {
+		return nil, err
+	}
+
+	fx, fy, err := entropyCenter(src, int(width), int(height))
+	if err != nil {
+		// fall back to a centered crop, like ResizeModeCover.
+		fx, fy = 0.5, 0.5
+	}
+
+	scale := math.Max(float64(width)/float64(srcW), float64(height)/float64(srcH))
+	resizedW := int(math.Round(float64(srcW) * scale))
+	resizedH := int(math.Round(float64(srcH) * scale))
+
+	x := clampInt(int(math.Round(fx*float64(resizedW)))-int(width)/2, 0, resizedW-int(width))
+	y := clampInt(int(math.Round(fy*float64(resizedH)))-int(height)/2, 0, resizedH-int(height))
+
+	return []string{
+		"-resize", fmt.Sprintf("%dx%d^", width, height),
+		"-gravity", "NorthWest",
+		"-crop", fmt.Sprintf("%dx%d+%d+%d", width, height, x, y),
+		// completely remove/reset the virtual canvas meta-data from the images.
+		"+repage",
+	}, nil
+}
+
+func imageDimensions(src string) (int, int, error) {
+	out, err := exec.Command("identify", "-format", "%w %h", src+"[0]").Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to identify image: %w", err)
+	}
+
+	var w, h int
+	_, err = fmt.Sscanf(string(out), "%d %d", &w, &h)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse dimensions %q: %w", out, err)
+	}
+	return w, h, nil
+}
+
+// entropyCenter downsamples src to an entropyGridSize x entropyGridSize
+// grayscale grid, finds the highest-entropy window matching the
+// width/height aspect ratio, and returns its center as a fraction
+// (0-1) of src's full dimensions.
+func entropyCenter(src string, width, height int) (float64, float64, error) {
+	out, err := exec.Command("convert", src+"[0]",
+		"-colorspace", "Gray",
+		"-resize", fmt.Sprintf("%dx%d!", entropyGridSize, entropyGridSize),
+		"gray:-").Output()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to sample image: %w", err)
+	}
+	if len(out) < entropyGridSize*entropyGridSize {
+		return 0, 0, fmt.Errorf("unexpected sample size: %d bytes", len(out))
+	}
+
+	aspect := float64(width) / float64(height)
+	winW := entropyGridSize
+	winH := int(float64(entropyGridSize) / aspect)
+	if winH > entropyGridSize {
+		winH = entropyGridSize
+		winW = int(float64(entropyGridSize) * aspect)
+	}
+	if winW < 1 {
+		winW = 1
+	}
+	if winH < 1 {
+		winH = 1
+	}
+
+	bestX, bestY, bestEntropy := 0, 0, -1.0
+	for y := 0; y+winH <= entropyGridSize; y++ {
+		for x := 0; x+winW <= entropyGridSize; x++ {
+			e := windowEntropy(out, x, y, winW, winH)
+			if e > bestEntropy {
+				bestEntropy = e
+				bestX, bestY = x, y
+			}
+		}
+	}
+
+	cx := (float64(bestX) + float64(winW)/2) / float64(entropyGridSize)
+	cy := (float64(bestY) + float64(winH)/2) / float64(entropyGridSize)
+	return cx, cy, nil
+}
+
+// windowEntropy computes the Shannon entropy H = -Σ p_i log2(p_i) of
+// an 8-bin luminance histogram over the given window of a
+// gray-colorspace pixel buffer laid out row-major.
+func windowEntropy(pixels []byte, x, y, w, h int) float64 {
+	var hist [8]int
+	total := 0
+	for j := y; j < y+h; j++ {
+		for i := x; i < x+w; i++ {
+			hist[pixels[j*entropyGridSize+i]>>5]++
+			total++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var entropy float64
+	for _, c := range hist {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func clampInt(v, min, max int) int {
+	if max < min {
+		return min
+	}
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,203 @@
+// Package imaging provides a pure Go space.ImageResizer implementation,
+// so that Space can be deployed without an ImageMagick installation.
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/disintegration/imaging"
+
+	"github.com/szxp/space"
+)
+
+// ImageResizer resizes images using github.com/disintegration/imaging
+// instead of shelling out to the ImageMagick convert binary. It decodes
+// with the standard image package plus the registered JPEG/PNG/GIF
+// decoders, auto-orients from EXIF and re-encodes in the requested
+// output format, or in the source format when none is requested.
+type ImageResizer struct {
+	// Quality is the JPEG encoding quality, 1-100. Zero means 75.
+	Quality int
+}
+
+// formatsByName maps an output format, as negotiated from the Accept
+// header, to the imaging.Format it encodes to. WebP and AVIF aren't
+// in the list since github.com/disintegration/imaging can't encode
+// them; operators shouldn't put them in AllowedOutputFormats when
+// running this backend.
+var formatsByName = map[string]imaging.Format{
+	"jpg":  imaging.JPEG,
+	"jpeg": imaging.JPEG,
+	"png":  imaging.PNG,
+	"gif":  imaging.GIF,
+	"tif":  imaging.TIFF,
+	"tiff": imaging.TIFF,
+	"bmp":  imaging.BMP,
+}
+
+func outputFormat(format, src string) (imaging.Format, error) {
+	if format == "" {
+		f, err := imaging.FormatFromExtension(filepath.Ext(src))
+		if err != nil {
+			return imaging.JPEG, nil
+		}
+		return f, nil
+	}
+
+	f, ok := formatsByName[format]
+	if !ok {
+		return 0, fmt.Errorf("unsupported output format: %s", format)
+	}
+	return f, nil
+}
+
+func (r *ImageResizer) Resize(dst, src string, width, height uint64, mode int8, format string, quality int) error {
+	img, err := imaging.Open(src, imaging.AutoOrientation(true))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	resized := resize(img, int(width), int(height), mode)
+
+	outFormat, err := outputFormat(format, src)
+	if err != nil {
+		return err
+	}
+
+	// A per-preset quality wins over the backend-wide default; absent
+	// either, fall back to 75.
+	if quality == 0 {
+		quality = r.Quality
+	}
+	if quality == 0 {
+		quality = 75
+	}
+
+	f, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create thumbnail file: %w", err)
+	}
+	defer f.Close()
+
+	err = imaging.Encode(f, resized, outFormat, imaging.JPEGQuality(quality))
+	if err != nil {
+		return fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+	return nil
+}
+
+// ResizeAnimated resizes every frame of an animated GIF and re-encodes
+// the result as an animated GIF, carrying over each frame's delay,
+// disposal method and the loop count, instead of flattening to the
+// first frame like Resize does. Only GIF output is supported, since
+// that's the only animated format handled so far.
+func (r *ImageResizer) ResizeAnimated(dst, src string, width, height uint64, mode int8, format string, quality int) error {
+	if format != "" && format != "gif" {
+		return fmt.Errorf("animated thumbnails only support gif output, got %q", format)
+	}
+	if mode == space.ResizeModeSmartCrop {
+		return fmt.Errorf("smart crop is not supported for animated thumbnails")
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open source: %w", err)
+	}
+	defer f.Close()
+
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		return fmt.Errorf("failed to decode gif: %w", err)
+	}
+
+	coalesced := coalesce(g)
+
+	out := &gif.GIF{LoopCount: g.LoopCount}
+	for i, frame := range coalesced {
+		resized := resize(frame, int(width), int(height), mode)
+
+		paletted := image.NewPaletted(resized.Bounds(), palette.Plan9)
+		draw.FloydSteinberg.Draw(paletted, resized.Bounds(), resized, image.Point{})
+
+		out.Image = append(out.Image, paletted)
+		out.Delay = append(out.Delay, g.Delay[i])
+		out.Disposal = append(out.Disposal, g.Disposal[i])
+	}
+
+	of, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create thumbnail file: %w", err)
+	}
+	defer of.Close()
+
+	err = gif.EncodeAll(of, out)
+	if err != nil {
+		return fmt.Errorf("failed to encode animated thumbnail: %w", err)
+	}
+	return nil
+}
+
+// coalesce expands every frame of g to the full logical-screen canvas,
+// compositing it on top of the previous frames per their disposal
+// method. gif.DecodeAll leaves each frame's bounds as whatever the
+// encoder wrote, usually just the region that changed from the frame
+// before, so resizing a frame on its own would resize that patch
+// relative to its own (smaller) bounds instead of the full canvas —
+// this mirrors what ImageMagick's -coalesce does for the imagemagick
+// backend.
+func coalesce(g *gif.GIF) []*image.RGBA {
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+
+	var snapshot *image.RGBA
+	frames := make([]*image.RGBA, len(g.Image))
+	for i, frame := range g.Image {
+		if g.Disposal[i] == gif.DisposalPrevious {
+			snapshot = image.NewRGBA(canvas.Bounds())
+			draw.Draw(snapshot, canvas.Bounds(), canvas, canvas.Bounds().Min, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		out := image.NewRGBA(canvas.Bounds())
+		draw.Draw(out, canvas.Bounds(), canvas, canvas.Bounds().Min, draw.Src)
+		frames[i] = out
+
+		switch g.Disposal[i] {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = snapshot
+		}
+	}
+	return frames
+}
+
+func resize(img image.Image, width, height int, mode int8) image.Image {
+	switch mode {
+	case space.ResizeModeCover:
+		if width > 0 && height > 0 {
+			return imaging.Fill(img, width, height, imaging.Center, imaging.Lanczos)
+		}
+		return imaging.Resize(img, width, height, imaging.Lanczos)
+	case space.ResizeModeSmartCrop:
+		if width > 0 && height > 0 {
+			return smartCrop(img, width, height)
+		}
+		return imaging.Resize(img, width, height, imaging.Lanczos)
+	case space.ResizeModeStretch:
+		return imaging.Resize(img, width, height, imaging.Lanczos)
+	default: // space.ResizeModeFit
+		if width > 0 && height > 0 {
+			return imaging.Fit(img, width, height, imaging.Lanczos)
+		}
+		return imaging.Resize(img, width, height, imaging.Lanczos)
+	}
+}
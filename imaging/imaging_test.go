@@ -0,0 +1,105 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+)
+
+// solidPaletted returns a paletted frame of size w x h, offset at
+// (x, y) within the logical screen, filled entirely with c.
+func solidPaletted(x, y, w, h int, c color.Color) *image.Paletted {
+	p := image.NewPaletted(image.Rect(x, y, x+w, y+h), color.Palette{color.Transparent, c})
+	for py := y; py < y+h; py++ {
+		for px := x; px < x+w; px++ {
+			p.Set(px, py, c)
+		}
+	}
+	return p
+}
+
+func TestCoalesceExpandsPartialFramesToFullCanvas(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			solidPaletted(0, 0, 4, 4, red),
+			solidPaletted(1, 1, 2, 2, blue),
+		},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalNone},
+		Delay:    []int{0, 0},
+		Config:   image.Config{Width: 4, Height: 4},
+	}
+
+	frames := coalesce(g)
+	if len(frames) != 2 {
+		t.Fatalf("len(frames) = %d, want 2", len(frames))
+	}
+
+	for _, f := range frames {
+		if f.Bounds() != image.Rect(0, 0, 4, 4) {
+			t.Fatalf("frame bounds = %v, want full 4x4 canvas", f.Bounds())
+		}
+	}
+
+	// Second frame only repainted the center 2x2 patch blue; the red
+	// background from frame one must still show through untouched
+	// corners instead of being cropped away.
+	if got := frames[1].At(0, 0); !sameColor(got, red) {
+		t.Fatalf("corner of frame 2 = %v, want red background to persist", got)
+	}
+	if got := frames[1].At(1, 1); !sameColor(got, blue) {
+		t.Fatalf("center of frame 2 = %v, want blue patch", got)
+	}
+}
+
+func TestCoalesceDisposalBackgroundClearsPatchBeforeNextFrame(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			solidPaletted(0, 0, 4, 4, red),
+			solidPaletted(1, 1, 2, 2, blue),
+			solidPaletted(0, 0, 0, 0, red), // placeholder, replaced below by a 1x1 patch outside the disposed area
+		},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalBackground, gif.DisposalNone},
+		Delay:    []int{0, 0, 0},
+		Config:   image.Config{Width: 4, Height: 4},
+	}
+	g.Image[2] = solidPaletted(3, 3, 1, 1, red)
+
+	frames := coalesce(g)
+	if got := frames[2].At(1, 1); !sameColor(got, color.RGBA{0, 0, 0, 0}) {
+		t.Fatalf("patch disposed as DisposalBackground should be transparent in the next frame, got %v", got)
+	}
+}
+
+func TestCoalesceDisposalPreviousRestoresPriorCanvas(t *testing.T) {
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			solidPaletted(0, 0, 4, 4, red),
+			solidPaletted(1, 1, 2, 2, blue),
+			solidPaletted(3, 3, 1, 1, red),
+		},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalNone},
+		Delay:    []int{0, 0, 0},
+		Config:   image.Config{Width: 4, Height: 4},
+	}
+
+	frames := coalesce(g)
+	if got := frames[2].At(1, 1); !sameColor(got, red) {
+		t.Fatalf("DisposalPrevious should restore the canvas from before the blue patch, got %v", got)
+	}
+}
+
+func sameColor(a, b color.Color) bool {
+	ar, ag, ab, aa := a.RGBA()
+	br, bg, bb, ba := b.RGBA()
+	return ar == br && ag == bg && ab == bb && aa == ba
+}
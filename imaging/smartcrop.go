@@ -0,0 +1,118 @@
+package imaging
+
+import (
+	"image"
+	"math"
+
+	"github.com/disintegration/imaging"
+)
+
+// entropyGridSize is the side length of the downsampled grayscale
+// grid that the saliency scan runs over.
+const entropyGridSize = 32
+
+// smartCrop resizes img to fill width x height like imaging.Fill, but
+// crops around the window of highest Shannon entropy instead of
+// always cropping from the center, so portraits and off-center
+// subjects aren't decapitated.
+//
+// There's no face-detection fallback here: space.ServerConfig.FaceCascadeFile
+// is explicitly descoped and rejected by NewServer, not silently ignored.
+func smartCrop(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW == 0 || srcH == 0 {
+		return imaging.Fill(img, width, height, imaging.Center, imaging.Lanczos)
+	}
+
+	fx, fy := entropyCenter(img, width, height)
+
+	scale := math.Max(float64(width)/float64(srcW), float64(height)/float64(srcH))
+	resizedW := int(math.Round(float64(srcW) * scale))
+	resizedH := int(math.Round(float64(srcH) * scale))
+	resized := imaging.Resize(img, resizedW, resizedH, imaging.Lanczos)
+
+	x := clampInt(int(math.Round(fx*float64(resizedW)))-width/2, 0, resizedW-width)
+	y := clampInt(int(math.Round(fy*float64(resizedH)))-height/2, 0, resizedH-height)
+
+	return imaging.Crop(resized, image.Rect(x, y, x+width, y+height))
+}
+
+// entropyCenter downsamples img to an entropyGridSize x entropyGridSize
+// grayscale grid, finds the highest-entropy window matching the
+// width/height aspect ratio, and returns its center as a fraction
+// (0-1) of img's full dimensions.
+func entropyCenter(img image.Image, width, height int) (float64, float64) {
+	grid := imaging.Resize(img, entropyGridSize, entropyGridSize, imaging.Box)
+	gray := imaging.Grayscale(grid)
+
+	aspect := float64(width) / float64(height)
+	winW := entropyGridSize
+	winH := int(float64(entropyGridSize) / aspect)
+	if winH > entropyGridSize {
+		winH = entropyGridSize
+		winW = int(float64(entropyGridSize) * aspect)
+	}
+	if winW < 1 {
+		winW = 1
+	}
+	if winH < 1 {
+		winH = 1
+	}
+
+	bestX, bestY, bestEntropy := 0, 0, -1.0
+	for y := 0; y+winH <= entropyGridSize; y++ {
+		for x := 0; x+winW <= entropyGridSize; x++ {
+			e := windowEntropy(gray, x, y, winW, winH)
+			if e > bestEntropy {
+				bestEntropy = e
+				bestX, bestY = x, y
+			}
+		}
+	}
+
+	cx := (float64(bestX) + float64(winW)/2) / float64(entropyGridSize)
+	cy := (float64(bestY) + float64(winH)/2) / float64(entropyGridSize)
+	return cx, cy
+}
+
+// windowEntropy computes the Shannon entropy H = -Σ p_i log2(p_i) of
+// an 8-bin luminance histogram over the given window of a grayscale
+// image.
+func windowEntropy(gray *image.NRGBA, x, y, w, h int) float64 {
+	var hist [8]int
+	total := 0
+	for j := y; j < y+h; j++ {
+		for i := x; i < x+w; i++ {
+			lum := gray.NRGBAAt(i, j).R
+			hist[lum>>5]++
+			total++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	var entropy float64
+	for _, c := range hist {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func clampInt(v, min, max int) int {
+	if max < min {
+		return min
+	}
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
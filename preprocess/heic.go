@@ -0,0 +1,38 @@
+package preprocess
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// HEICConverter converts a HEIC/HEIF photo to JPEG using
+// libheif's heif-convert, since Go's standard image decoders and
+// github.com/disintegration/imaging don't support HEIC.
+type HEICConverter struct{}
+
+func (h *HEICConverter) Extensions() []string {
+	return []string{".heic", ".heif"}
+}
+
+func (h *HEICConverter) OutputExt() string {
+	return ".jpg"
+}
+
+func (h *HEICConverter) Process(ctx context.Context, srcPath string) (string, func(), error) {
+	tmpf, err := os.CreateTemp("", "heic-*.jpg")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpf.Name()
+	tmpf.Close()
+	cleanup := func() { os.Remove(tmpPath) }
+
+	_, err = exec.CommandContext(ctx, "heif-convert", srcPath, tmpPath).Output()
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to convert heic: %w", err)
+	}
+	return tmpPath, cleanup, nil
+}
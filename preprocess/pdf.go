@@ -0,0 +1,48 @@
+// Package preprocess provides built-in space.Preprocessor
+// implementations that normalize non-image sources into an
+// intermediate JPEG or PNG image before resizing.
+package preprocess
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// PDFRasterizer rasterizes a PDF's first page into a JPEG using
+// Ghostscript, so it can be thumbnailed like any other image.
+type PDFRasterizer struct{}
+
+func (p *PDFRasterizer) Extensions() []string {
+	return []string{".pdf"}
+}
+
+func (p *PDFRasterizer) OutputExt() string {
+	return ".jpg"
+}
+
+func (p *PDFRasterizer) Process(ctx context.Context, srcPath string) (string, func(), error) {
+	tmpf, err := os.CreateTemp("", "pdf-page-*.jpg")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpf.Name()
+	tmpf.Close()
+	cleanup := func() { os.Remove(tmpPath) }
+
+	args := []string{
+		"-dNOPAUSE", "-dBATCH", "-dSAFER",
+		"-sDEVICE=jpeg",
+		"-dFirstPage=1", "-dLastPage=1",
+		"-r150",
+		"-sOutputFile=" + tmpPath,
+		srcPath,
+	}
+	_, err = exec.CommandContext(ctx, "gs", args...).Output()
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to rasterize pdf: %w", err)
+	}
+	return tmpPath, cleanup, nil
+}
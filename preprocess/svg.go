@@ -0,0 +1,82 @@
+package preprocess
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// SVGRasterizer rasterizes an SVG to PNG using librsvg's
+// rsvg-convert.
+//
+// rsvg-convert has no CLI flag to disable resolving external
+// references (xlink:href/href pointing at file:// or network URIs),
+// a well-known SSRF/local-file-disclosure vector in SVG thumbnailers.
+// Process rejects any such reference before invoking rsvg-convert
+// rather than passing attacker-controlled SVGs through unchecked;
+// data: URIs (inline images, the common legitimate case) are still
+// allowed. An SVG that does reference an external resource is always
+// refused; Process has no option to let it through.
+type SVGRasterizer struct{}
+
+func (s *SVGRasterizer) Extensions() []string {
+	return []string{".svg"}
+}
+
+func (s *SVGRasterizer) OutputExt() string {
+	return ".png"
+}
+
+// hrefPattern captures the value of an xlink:href or href attribute,
+// single- or double-quoted.
+var hrefPattern = regexp.MustCompile(`(?i)(?:xlink:)?href\s*=\s*"([^"]*)"|(?:xlink:)?href\s*=\s*'([^']*)'`)
+
+// hasExternalRef reports whether src contains an xlink:href/href
+// attribute pointing outside the document itself: a data: URI (inline
+// image data) and a bare same-document fragment ("#id") are the only
+// values that can't make rsvg-convert fetch a local file or reach out
+// over the network, so anything else - an absolute URI, a
+// protocol-relative one, or a relative path that could traverse to
+// another file on disk - is treated as external.
+func hasExternalRef(src []byte) bool {
+	for _, m := range hrefPattern.FindAllSubmatch(src, -1) {
+		value := m[1]
+		if len(value) == 0 {
+			value = m[2]
+		}
+		v := strings.TrimSpace(string(value))
+		if v == "" || strings.HasPrefix(v, "#") || strings.HasPrefix(strings.ToLower(v), "data:") {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func (s *SVGRasterizer) Process(ctx context.Context, srcPath string) (string, func(), error) {
+	src, err := os.ReadFile(srcPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read svg: %w", err)
+	}
+	if hasExternalRef(src) {
+		return "", nil, fmt.Errorf("svg references an external resource, refusing to rasterize")
+	}
+
+	tmpf, err := os.CreateTemp("", "svg-*.png")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpf.Name()
+	tmpf.Close()
+	cleanup := func() { os.Remove(tmpPath) }
+
+	_, err = exec.CommandContext(ctx, "rsvg-convert", "-o", tmpPath, srcPath).Output()
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to rasterize svg: %w", err)
+	}
+	return tmpPath, cleanup, nil
+}
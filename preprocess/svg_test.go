@@ -0,0 +1,27 @@
+package preprocess
+
+import "testing"
+
+func TestHasExternalRef(t *testing.T) {
+	cases := []struct {
+		name string
+		svg  string
+		want bool
+	}{
+		{"no href", `<svg><rect width="10" height="10"/></svg>`, false},
+		{"data uri", `<svg><image href="data:image/png;base64,AAAA"/></svg>`, false},
+		{"fragment", `<svg><use xlink:href="#icon"/></svg>`, false},
+		{"file uri", `<svg><image xlink:href="file:///etc/passwd"/></svg>`, true},
+		{"http uri", `<svg><image href="http://evil.example/x.png"/></svg>`, true},
+		{"protocol relative", `<svg><image href="//evil.example/x.png"/></svg>`, true},
+		{"relative path traversal", `<svg><image href="../../../../etc/passwd"/></svg>`, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := hasExternalRef([]byte(c.svg)); got != c.want {
+				t.Errorf("hasExternalRef(%q) = %v, want %v", c.svg, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package preprocess
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// VideoFrameExtractor extracts a video's first frame as a JPEG using
+// ffmpeg.
+type VideoFrameExtractor struct{}
+
+func (v *VideoFrameExtractor) Extensions() []string {
+	return []string{".mp4", ".mov", ".webm", ".mkv"}
+}
+
+func (v *VideoFrameExtractor) OutputExt() string {
+	return ".jpg"
+}
+
+func (v *VideoFrameExtractor) Process(ctx context.Context, srcPath string) (string, func(), error) {
+	tmpf, err := os.CreateTemp("", "video-frame-*.jpg")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmpf.Name()
+	tmpf.Close()
+	cleanup := func() { os.Remove(tmpPath) }
+
+	args := []string{
+		"-y",
+		"-i", srcPath,
+		"-frames:v", "1",
+		tmpPath,
+	}
+	_, err = exec.CommandContext(ctx, "ffmpeg", args...).CombinedOutput()
+	if err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to extract video frame: %w", err)
+	}
+	return tmpPath, cleanup, nil
+}
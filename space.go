@@ -1,7 +1,11 @@
 package space
 
 import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
+	"image/gif"
 	"io"
 	"mime"
 	"net/http"
@@ -12,6 +16,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 )
@@ -25,15 +30,108 @@ type ServerConfig struct {
 	AllowedThumbnailSizes ThumbnailSizes
 	AllowedHosts          []string
 	ThumbnailMaxAge       int64
-	Logger                hclog.Logger
+
+	// Presets is the centrally defined set of named thumbnail
+	// presets, addressable as ?p=name or /thumbnail/foo.jpg/name.
+	Presets Presets
+
+	// AllowLegacySizes, when true, keeps accepting the raw w/h/m
+	// query params validated against AllowedThumbnailSizes for
+	// clients that haven't migrated to presets yet. A deployment with
+	// no Presets configured always accepts them regardless of this
+	// setting, since there's nothing to migrate to and an existing
+	// config.toml from before presets existed must keep working
+	// unchanged; set this explicitly to false once Presets is
+	// populated to require every request to go through one.
+	AllowLegacySizes bool
+
+	// AllowedOutputFormats is the ordered allowlist of output
+	// formats (e.g. "webp", "jpg") that content negotiation may
+	// pick via the Accept header. The source format is kept if none
+	// of them are accepted by the client, except for a preprocessed
+	// source, which gets its Preprocessor's OutputExt format.
+	AllowedOutputFormats []string
+
+	// AnimatedThumbnails, when true, resizes every frame of an
+	// animated GIF source and serves an animated thumbnail instead
+	// of collapsing it to its first frame.
+	AnimatedThumbnails bool
+
+	// FaceCascadeFile was requested alongside ResizeModeSmartCrop as a
+	// Haar-cascade face-detection fallback for when entropy-based
+	// cropping misses a face, but it is explicitly descoped from that
+	// change: a cascade classifier is a substantial feature in its
+	// own right (cascade parsing, integral images, multi-scale
+	// sliding-window search) and shipping a half-working version was
+	// judged worse than shipping entropy-only cropping and tracking
+	// face detection as a separate follow-up request. NewServer
+	// rejects a non-empty value so that descope is loud, not silent.
+	FaceCascadeFile string
+
+	// ThumbnailCacheMaxBytes bounds the total size of ThumbnailDir.
+	// Zero disables size-based eviction. A thumbnail that is itself
+	// larger than ThumbnailCacheMaxBytes is kept anyway rather than
+	// evicted right after creation; set it above the largest
+	// thumbnail the server can produce to get real size bounding.
+	ThumbnailCacheMaxBytes int64
+
+	// ThumbnailCacheMaxAge evicts cached thumbnails not served
+	// within this many seconds. Zero disables age-based eviction.
+	ThumbnailCacheMaxAge int64
+
+	// AdminToken guards /admin/cache/stats and /admin/cache/purge.
+	// Empty disables both endpoints.
+	AdminToken string
+
+	// Preprocessors normalizes non-image sources (PDFs, HEIC photos,
+	// SVGs, video) into an intermediate image before
+	// ImageResizer.Resize runs. Each preprocessor's extensions are
+	// merged into AllowedExts.
+	Preprocessors []Preprocessor
+
+	Logger hclog.Logger
+}
+
+// Preprocessor normalizes a source file into an intermediate
+// JPEG/PNG image before resizing, so that ImageResizer never has to
+// deal with non-image formats like PDF, HEIC, SVG or video directly.
+type Preprocessor interface {
+	// Extensions returns the lowercase, dot-prefixed source
+	// extensions this preprocessor handles, e.g. []string{".pdf"}.
+	Extensions() []string
+
+	// OutputExt returns the lowercase, dot-prefixed extension of the
+	// intermediate image Process produces, e.g. ".jpg". It is used as
+	// the cached thumbnail's default output extension when content
+	// negotiation didn't pick one, since the source's own extension
+	// (e.g. ".pdf") no longer describes the thumbnail bytes.
+	OutputExt() string
+
+	// Process turns srcPath into a normalized intermediate image,
+	// returning its path and a cleanup func to remove any temp files
+	// it created.
+	Process(ctx context.Context, srcPath string) (newSrcPath string, cleanup func(), err error)
 }
 
 type Server struct {
 	conf    *ServerConfig
 	handler http.Handler
 
-	thumbnailMutex    sync.Mutex
+	thumbnailMutex sync.Mutex
+
+	// pendingThumbnails dedups concurrent first-requests for the same
+	// output file, keyed by its full thumbnail path (not thumbnail.Key):
+	// two requests for the same source but different presets, negotiated
+	// formats, or animated-vs-static variants resolve to different paths
+	// and so must build independently instead of racing on one channel
+	// set.
 	pendingThumbnails map[string][]chan error
+
+	animatedMutex sync.Mutex
+	animated      map[string]bool
+
+	cache         *ThumbnailCache
+	preprocessors map[string]Preprocessor
 }
 
 func NewServer(conf ServerConfig) (*Server, error) {
@@ -41,19 +139,94 @@ func NewServer(conf ServerConfig) (*Server, error) {
 		conf.Logger = hclog.NewNullLogger()
 	}
 
+	if conf.FaceCascadeFile != "" {
+		return nil, fmt.Errorf("FaceCascadeFile: Haar-cascade face detection is explicitly descoped, not implemented; leave it unset")
+	}
+
 	s := &Server{
 		conf:              &conf,
 		pendingThumbnails: make(map[string][]chan error),
+		animated:          make(map[string]bool),
+		preprocessors:     make(map[string]Preprocessor),
+	}
+
+	for _, pp := range conf.Preprocessors {
+		for _, ext := range pp.Extensions() {
+			s.preprocessors[ext] = pp
+			if !hasExt(conf.AllowedExts, ext) {
+				conf.AllowedExts = append(conf.AllowedExts, ext)
+			}
+		}
+	}
+
+	if conf.ThumbnailCacheMaxBytes > 0 || conf.ThumbnailCacheMaxAge > 0 {
+		cache, err := NewThumbnailCache(
+			conf.ThumbnailDir,
+			conf.ThumbnailCacheMaxBytes,
+			time.Duration(conf.ThumbnailCacheMaxAge)*time.Second,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize thumbnail cache: %w", err)
+		}
+		s.cache = cache
 	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/source/", s.sourceHandler())
 	mux.Handle("/thumbnail/", s.thumbnailHandler())
+	mux.Handle("/admin/cache/stats", s.adminHandler(s.cacheStatsHandler()))
+	mux.Handle("/admin/cache/purge", s.adminHandler(s.cachePurgeHandler()))
 
 	s.handler = http.Handler(mux)
 	return s, nil
 }
 
+// adminHandler guards next with ServerConfig.AdminToken, checked as a
+// bearer token in the Authorization header. An empty AdminToken
+// disables the wrapped handler entirely.
+func (s *Server) adminHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented := r.Header.Get("authorization")
+		expected := "Bearer " + s.conf.AdminToken
+		if s.conf.AdminToken == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(expected)) != 1 {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) cacheStatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.cache == nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("content-type", "application/json")
+		err := json.NewEncoder(w).Encode(s.cache.Stats())
+		if err != nil {
+			s.conf.Logger.Error("Failed to encode cache stats", "error", err)
+		}
+	})
+}
+
+func (s *Server) cachePurgeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "Error", http.StatusBadRequest)
+			return
+		}
+		if s.cache == nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		s.cache.Purge()
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if !s.isHostAllowed(r.Host) {
 		s.conf.Logger.Error("Invalid host header", "host", r.Host)
@@ -113,6 +286,8 @@ func (s *Server) serveThumbnail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("vary", "accept")
+
 	f, err := s.openThumbnail(th)
 	if err != nil && !os.IsNotExist(err) {
 		s.conf.Logger.Error("Failed to open thumbnail", "thumbnail", th, "error", err)
@@ -142,7 +317,7 @@ func (s *Server) serveThumbnail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	http.ServeContent(w, r, "", fi.ModTime(), f)
+	http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
 	return
 }
 
@@ -197,77 +372,286 @@ func (ts *ThumbnailSize) UnmarshalText(s string) error {
 	return nil
 }
 
-type thumbnail struct {
-	Key    string
+// Presets is the centrally configured set of named thumbnail presets,
+// the canonical public thumbnail API. See ServerConfig.Presets.
+type Presets []*Preset
+
+func (ps Presets) Get(name string) *Preset {
+	for _, p := range ps {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Preset is a named, fixed width/height/mode combination, addressable
+// as ?p=name or /thumbnail/foo.jpg/name, so that URL construction is
+// stable and the allowed set is centrally defined.
+type Preset struct {
+	Name   string
 	Width  uint64
 	Height uint64
 	Mode   int8
+
+	// Quality is the output encoding quality for lossy formats
+	// (e.g. JPEG), passed to ImageResizer.Resize. Zero means the
+	// backend's own default. Output format itself isn't set here:
+	// it's negotiated per request from the Accept header, see
+	// ServerConfig.AllowedOutputFormats.
+	Quality int
+}
+
+type thumbnail struct {
+	Key      string
+	Preset   string
+	Width    uint64
+	Height   uint64
+	Mode     int8
+	Animated bool
+
+	// Format is the negotiated output format, e.g. "webp" or "jpg".
+	// Empty means keep the source format.
+	Format string
+
+	// Quality is the preset's output encoding quality, carried over
+	// from Preset.Quality. Zero means the backend's own default.
+	Quality int
+}
+
+// formatMimeTypes maps an output format (as used in thumbnail.Format
+// and ServerConfig.AllowedOutputFormats) to the MIME type clients
+// advertise for it in the Accept header.
+var formatMimeTypes = map[string]string{
+	"jpg":  "image/jpeg",
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"gif":  "image/gif",
+	"webp": "image/webp",
+	"avif": "image/avif",
+}
+
+// mime.TypeByExtension relies on the OS's mime.types database, which
+// often doesn't know newer formats like webp/avif. Register them
+// explicitly so both the HEAD branch of serveThumbnail and
+// http.ServeContent's own mime.TypeByExtension lookup resolve them
+// correctly instead of falling back to content-sniffing.
+func init() {
+	for ext, typ := range formatMimeTypes {
+		mime.AddExtensionType("."+ext, typ)
+	}
+}
+
+// negotiateFormat picks the most preferred format from allowed (in
+// order) that the client's Accept header advertises. It returns ""
+// if accept is empty or none of the allowed formats are accepted,
+// meaning the source format should be kept.
+func negotiateFormat(accept string, allowed []string) string {
+	if accept == "" {
+		return ""
+	}
+
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		accepted[mediaType] = true
+	}
+
+	for _, format := range allowed {
+		if accepted[formatMimeTypes[format]] {
+			return format
+		}
+	}
+	return ""
 }
 
 func (s *Server) parseThumbnail(r *http.Request) (*thumbnail, error) {
 	th := &thumbnail{}
 
 	key := removePrefix(r.URL.Path, "/thumbnail/")
+	q := r.URL.Query()
+
+	presetName := q.Get("p")
+	if presetName == "" {
+		if i := strings.LastIndex(key, "/"); i != -1 {
+			if s.conf.Presets.Get(key[i+1:]) != nil {
+				presetName = key[i+1:]
+				key = key[:i]
+			}
+		}
+	}
+
 	err := s.validateKey(key)
 	if err != nil {
 		return nil, err
 	}
 	th.Key = key
 
-	q := r.URL.Query()
+	if presetName != "" {
+		preset := s.conf.Presets.Get(presetName)
+		if preset == nil {
+			return nil, fmt.Errorf("invalid preset: %s", presetName)
+		}
+		th.Preset = preset.Name
+		th.Width = preset.Width
+		th.Height = preset.Height
+		th.Mode = preset.Mode
+		th.Quality = preset.Quality
+	} else {
+		if len(s.conf.Presets) > 0 && !s.conf.AllowLegacySizes {
+			return nil, fmt.Errorf("no preset given and legacy thumbnail sizes are disabled")
+		}
 
-	w := q.Get("w")
-	if w != "" {
-		width, err := strconv.ParseUint(w, 10, 64)
-		if err != nil {
-			return nil, err
+		w := q.Get("w")
+		if w != "" {
+			width, err := strconv.ParseUint(w, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			th.Width = width
 		}
-		th.Width = width
-	}
 
-	h := q.Get("h")
-	if h != "" {
-		height, err := strconv.ParseUint(h, 10, 64)
-		if err != nil {
-			return nil, err
+		h := q.Get("h")
+		if h != "" {
+			height, err := strconv.ParseUint(h, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			th.Height = height
+		}
+
+		m := q.Get("m")
+		if m != "" {
+			switch m {
+			case "1":
+				th.Mode = ResizeModeFit
+			case "2":
+				th.Mode = ResizeModeCover
+			case "3":
+				th.Mode = ResizeModeStretch
+			case "4":
+				th.Mode = ResizeModeSmartCrop
+			default:
+				return nil, fmt.Errorf("invalid mode: %s", m)
+			}
 		}
-		th.Height = height
-	}
 
-	m := q.Get("m")
-	if m != "" {
-		switch m {
-		case "1":
-			th.Mode = ResizeModeFit
-		case "2":
-			th.Mode = ResizeModeCover
-		case "3":
-			th.Mode = ResizeModeStretch
-		default:
-			return nil, fmt.Errorf("invalid mode: %s", m)
+		if (th.Width != 0 || th.Height != 0) &&
+			!s.conf.AllowedThumbnailSizes.IsValid(th.Width, th.Height) {
+			return nil, fmt.Errorf("invalid size: %dx%d", th.Width, th.Height)
 		}
+
+		if th.Width == 0 && th.Height == 0 {
+			th.Width = s.conf.DefaultThumbnailWidth
+		}
+	}
+
+	if s.conf.AnimatedThumbnails && strings.EqualFold(path.Ext(th.Key), ".gif") {
+		th.Animated = s.isAnimatedSource(th.Key)
 	}
 
-	if (th.Width != 0 || th.Height != 0) &&
-		!s.conf.AllowedThumbnailSizes.IsValid(th.Width, th.Height) {
-		return nil, fmt.Errorf("invalid size: %dx%d", th.Width, th.Height)
+	// Animated thumbnails are always served as GIF: both ImageResizer
+	// backends reject ResizeAnimated with any other format, and an
+	// animated source shouldn't fail just because the client's Accept
+	// header also happens to list a still-image format.
+	if !th.Animated {
+		th.Format = negotiateFormat(r.Header.Get("accept"), s.conf.AllowedOutputFormats)
 	}
 
-	if th.Width == 0 && th.Height == 0 {
-		th.Width = s.conf.DefaultThumbnailWidth
+	// A preprocessed source (PDF, HEIC/HEIF, SVG, video, ...) is never
+	// thumbnailed in its own format, so its source extension must not
+	// leak into RelPath() as the cached file's extension. Default to
+	// whatever the preprocessor actually encodes when negotiation
+	// didn't already pick an output format.
+	if th.Format == "" {
+		if pp, ok := s.preprocessors[strings.ToLower(path.Ext(th.Key))]; ok {
+			th.Format = strings.TrimPrefix(pp.OutputExt(), ".")
+		}
 	}
 
 	return th, nil
 }
 
+// maxAnimatedEntries bounds s.animated. Once it's reached, the whole
+// map is dropped and starts repopulating from scratch, rather than
+// growing for the life of the process.
+const maxAnimatedEntries = 4096
+
+// isAnimatedSource reports whether the source identified by key is a
+// GIF with more than one frame. The verdict is memoized per key, since
+// deciding it requires fully decoding the source and that decode would
+// otherwise repeat on every request for the same source, not just on
+// cache misses. A failed decode (source missing, not yet uploaded,
+// transient I/O error, ...) is never memoized, so a source that starts
+// out unreadable still gets picked up correctly once it exists.
+func (s *Server) isAnimatedSource(key string) bool {
+	s.animatedMutex.Lock()
+	animated, ok := s.animated[key]
+	s.animatedMutex.Unlock()
+	if ok {
+		return animated
+	}
+
+	animated, err := s.decodeIsAnimated(key)
+	if err != nil {
+		return false
+	}
+
+	s.animatedMutex.Lock()
+	if len(s.animated) >= maxAnimatedEntries {
+		s.animated = make(map[string]bool)
+	}
+	s.animated[key] = animated
+	s.animatedMutex.Unlock()
+
+	return animated
+}
+
+func (s *Server) decodeIsAnimated(key string) (bool, error) {
+	f, err := os.Open(s.sourcePath(key))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	g, err := gif.DecodeAll(f)
+	if err != nil {
+		return false, err
+	}
+	return len(g.Image) > 1, nil
+}
+
 func (th *thumbnail) RelPath() string {
 	dot := strings.LastIndex(th.Key, ".")
-	return fmt.Sprintf("%s-w%d-h%d-m%d%s",
+	ext := th.Key[dot:]
+	if th.Format != "" {
+		ext = "." + th.Format
+	}
+
+	anim := ""
+	if th.Animated {
+		anim = "-a"
+	}
+
+	if th.Preset != "" {
+		return fmt.Sprintf("%s-p%s-w%d-h%d-m%d%s%s",
+			th.Key[:dot],
+			th.Preset,
+			th.Width,
+			th.Height,
+			th.Mode,
+			anim,
+			ext,
+		)
+	}
+
+	return fmt.Sprintf("%s-w%d-h%d-m%d%s%s",
 		th.Key[:dot],
 		th.Width,
 		th.Height,
 		th.Mode,
-		th.Key[dot:],
+		anim,
+		ext,
 	)
 }
 
@@ -280,13 +664,16 @@ func (s *Server) openThumbnail(th *thumbnail) (*os.File, error) {
 	s.conf.Logger.Debug("Open thumbnail", "path", path)
 	f, err := os.Open(path)
 	if (err != nil && !os.IsNotExist(err)) || err == nil {
+		if err == nil && s.cache != nil {
+			s.cache.touch(path)
+		}
 		return f, err
 	}
 
 	s.thumbnailMutex.Lock()
 	ch := make(chan error, 1)
-	s.pendingThumbnails[th.Key] = append(s.pendingThumbnails[th.Key], ch)
-	if len(s.pendingThumbnails[th.Key]) == 1 {
+	s.pendingThumbnails[path] = append(s.pendingThumbnails[path], ch)
+	if len(s.pendingThumbnails[path]) == 1 {
 		go s.createThumbnail(th, path)
 	}
 	s.thumbnailMutex.Unlock()
@@ -303,11 +690,11 @@ func (s *Server) createThumbnail(th *thumbnail, path string) {
 	s.conf.Logger.Debug("Stat thumbnail", "path", path)
 	_, err := os.Stat(path)
 	if err != nil && !os.IsNotExist(err) {
-		s.sendThumbnailResult(th.Key, err)
+		s.sendThumbnailResult(path, err)
 		return
 	}
 	if err == nil {
-		s.sendThumbnailResult(th.Key, nil)
+		s.sendThumbnailResult(path, nil)
 		return
 	}
 
@@ -315,52 +702,73 @@ func (s *Server) createThumbnail(th *thumbnail, path string) {
 	s.conf.Logger.Debug("Stat source", "path", src)
 	_, err = os.Stat(src)
 	if err != nil {
-		s.sendThumbnailResult(th.Key, err)
+		s.sendThumbnailResult(path, err)
 		return
 	}
 
+	if pp, ok := s.preprocessors[strings.ToLower(filepath.Ext(src))]; ok {
+		s.conf.Logger.Debug("Preprocess source", "path", src)
+		normalized, cleanup, err := pp.Process(context.Background(), src)
+		if err != nil {
+			s.sendThumbnailResult(path, fmt.Errorf("failed to preprocess source: %w", err))
+			return
+		}
+		defer cleanup()
+		src = normalized
+	}
+
 	thDir := filepath.Dir(path)
 	s.conf.Logger.Debug("MkDir", "path", thDir)
 	err = os.MkdirAll(thDir, 0754)
 	if err != nil {
-		s.sendThumbnailResult(th.Key, err)
+		s.sendThumbnailResult(path, err)
 		return
 	}
 
 	s.conf.Logger.Debug("Create tmp file")
 	tmpf, err := os.CreateTemp(filepath.Dir(path), "tmp")
 	if err != nil {
-		s.sendThumbnailResult(th.Key, err)
+		s.sendThumbnailResult(path, err)
 		return
 	}
 	tmpPath := tmpf.Name()
 	defer func() { os.Remove(tmpPath) }()
 	tmpf.Close()
 
+	resize := s.conf.ImageResizer.Resize
+	if th.Animated {
+		resize = s.conf.ImageResizer.ResizeAnimated
+	}
+
 	s.conf.Logger.Debug("Create tmp thumbnail", "path", tmpPath)
-	err = s.conf.ImageResizer.Resize(tmpPath, src, th.Width, th.Height, th.Mode)
+	err = resize(tmpPath, src, th.Width, th.Height, th.Mode, th.Format, th.Quality)
 	if err != nil {
-		s.sendThumbnailResult(th.Key, err)
+		s.sendThumbnailResult(path, err)
 		return
 	}
 
 	s.conf.Logger.Debug("Rename tmp thumbnail", "old", tmpPath, "new", path)
 	err = os.Rename(tmpPath, path)
-	s.sendThumbnailResult(th.Key, err)
+	if err == nil && s.cache != nil {
+		if fi, statErr := os.Stat(path); statErr == nil {
+			s.cache.add(path, fi.Size(), time.Now())
+		}
+	}
+	s.sendThumbnailResult(path, err)
 	return
 }
 
-func (s *Server) sendThumbnailResult(key string, err error) {
+func (s *Server) sendThumbnailResult(path string, err error) {
 	s.thumbnailMutex.Lock()
 	defer s.thumbnailMutex.Unlock()
 
-	for _, ch := range s.pendingThumbnails[key] {
+	for _, ch := range s.pendingThumbnails[path] {
 		if err != nil {
 			ch <- err
 		}
 		close(ch)
 	}
-	s.pendingThumbnails[key] = s.pendingThumbnails[key][:0]
+	s.pendingThumbnails[path] = s.pendingThumbnails[path][:0]
 }
 
 func (s *Server) sourceHandler() http.Handler {
@@ -470,6 +878,15 @@ func keyFilepath(key string) string {
 	return filepath.FromSlash(key)
 }
 
+func hasExt(exts []string, ext string) bool {
+	for _, e := range exts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
 var keyRE *regexp.Regexp = regexp.MustCompile(`^[a-zA-Z0-9./-]+$`)
 
 func (s *Server) validateKey(key string) error {
@@ -528,8 +945,25 @@ const (
 
 	// 	Width and height emphatically given, original aspect ratio ignored.
 	ResizeModeStretch = 3
+
+	// Minimum values of width and height given, aspect ratio preserved,
+	// like ResizeModeCover, but cropped around the highest-entropy
+	// region of the source instead of always cropping from the center.
+	ResizeModeSmartCrop = 4
 )
 
 type ImageResizer interface {
-	Resize(dst, src string, width, height uint64, mode int8) error
+	// Resize resizes the image at src into dst. format, e.g. "webp"
+	// or "jpg", selects the output encoding; an empty format keeps
+	// the source format. quality is the output encoding quality for
+	// lossy formats; zero means the backend's own default.
+	Resize(dst, src string, width, height uint64, mode int8, format string, quality int) error
+
+	// ResizeAnimated resizes every frame of an animated source and
+	// re-encodes the result as an animated image, instead of
+	// collapsing it to a single frame like Resize does. quality is
+	// accepted for signature parity with Resize but is meaningless
+	// for the lossless, palette-based GIF output animated thumbnails
+	// are restricted to.
+	ResizeAnimated(dst, src string, width, height uint64, mode int8, format string, quality int) error
 }